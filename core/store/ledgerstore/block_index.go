@@ -0,0 +1,199 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The poly network is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The poly network is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the poly network.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"sync"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/types"
+)
+
+// blockNode is the in-memory representation of a known header, linked to its
+// parent so ancestor queries never need a disk read. It also keeps the
+// header itself, so GetHeader can be served straight from memory. Modeled on
+// Bytom's block node, minus a work/round field: unlike a PoW chain, poly's
+// ledger is a single BFT-finalized chain, so BlockIndex never picks the
+// canonical branch itself - it only mirrors whatever height->hash mapping
+// the ledger has already decided, via SetMainChain.
+type blockNode struct {
+	hash      common.Uint256
+	height    uint32
+	header    *types.Header
+	parent    *blockNode
+	mainChain bool
+}
+
+// BlockIndex holds a blockNode for every header BlockStore has ever seen, so
+// ancestor queries and GetHeader can be served in memory instead of
+// repeatedly hitting leveldb through getHeaderKey. SaveBlock and SaveHeader
+// keep it in sync with the batch committed in CommitTo. Headers whose parent
+// has not arrived yet are parked in an internal OrphanManage and re-attached
+// as soon as that parent is added.
+//
+// BlockIndex does not decide which branch is canonical. It has no
+// fork-choice rule of its own; SetMainChain is how the ledger (BlockStore,
+// driven by SaveBlockHash - the authoritative height->hash mapping) tells
+// the index which header occupies a height, so mainChain/BestHeight/
+// InMainChain can never disagree with the committed store.
+type BlockIndex struct {
+	lock       sync.RWMutex
+	nodeByHash map[common.Uint256]*blockNode
+	mainChain  map[uint32]*blockNode
+	children   map[common.Uint256][]common.Uint256
+	best       *blockNode
+	orphans    *OrphanManage
+}
+
+// NewBlockIndex returns an empty BlockIndex, ready to be filled in by
+// replaying stored headers.
+func NewBlockIndex() *BlockIndex {
+	return &BlockIndex{
+		nodeByHash: make(map[common.Uint256]*blockNode),
+		mainChain:  make(map[uint32]*blockNode),
+		children:   make(map[common.Uint256][]common.Uint256),
+		orphans:    NewOrphanManage(),
+	}
+}
+
+// AddHeader wires header into the index. If header's parent is not known yet
+// (and header is not a genesis header, i.e. PrevBlockHash is empty) it is
+// parked as an orphan and re-attached once that parent is added, instead of
+// being linked with a permanently nil parent. It does not change which
+// branch is canonical; call SetMainChain once the ledger has decided that.
+// It returns the attached node, or nil if header was parked as an orphan.
+func (this *BlockIndex) AddHeader(header *types.Header) *blockNode {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.addHeader(header)
+}
+
+func (this *BlockIndex) addHeader(header *types.Header) *blockNode {
+	hash := header.Hash()
+	if node, ok := this.nodeByHash[hash]; ok {
+		return node
+	}
+
+	parent, hasParent := this.nodeByHash[header.PrevBlockHash]
+	if !hasParent && header.PrevBlockHash != common.UINT256_EMPTY {
+		this.orphans.AddOrphan(header)
+		return nil
+	}
+
+	node := &blockNode{
+		hash:   hash,
+		height: header.Height,
+		header: header,
+		parent: parent,
+	}
+	this.nodeByHash[hash] = node
+	this.children[header.PrevBlockHash] = append(this.children[header.PrevBlockHash], hash)
+
+	// now that hash is known, any header that was waiting on it can attach
+	for _, pending := range this.orphans.Resolve(hash) {
+		this.addHeader(pending)
+	}
+	return node
+}
+
+// SetMainChain records that hash is the canonical header at height, mirroring
+// a decision the ledger has already made (BlockStore calls this from
+// SaveBlockHash, the authoritative height->hash mapping) rather than
+// computing one itself. Whatever node previously occupied height, if any, is
+// unmarked. It is a no-op if hash is not a known header yet.
+func (this *BlockIndex) SetMainChain(hash common.Uint256, height uint32) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.setMainChain(hash, height)
+}
+
+func (this *BlockIndex) setMainChain(hash common.Uint256, height uint32) {
+	node, ok := this.nodeByHash[hash]
+	if !ok {
+		return
+	}
+	if old, ok := this.mainChain[height]; ok {
+		old.mainChain = false
+	}
+	node.mainChain = true
+	this.mainChain[height] = node
+	if this.best == nil || height >= this.best.height {
+		this.best = node
+	}
+}
+
+// NodeByHash returns the node for hash, if known.
+func (this *BlockIndex) NodeByHash(hash common.Uint256) *blockNode {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.nodeByHash[hash]
+}
+
+// NodeByHeight returns the main-chain node at height, if any.
+func (this *BlockIndex) NodeByHeight(height uint32) *blockNode {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	return this.mainChain[height]
+}
+
+// Ancestor walks node's parent chain back to height, returning nil if node
+// has no ancestor at that height.
+func (this *BlockIndex) Ancestor(node *blockNode, height uint32) *blockNode {
+	if node == nil || height > node.height {
+		return nil
+	}
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	for n := node; n != nil; n = n.parent {
+		if n.height == height {
+			return n
+		}
+	}
+	return nil
+}
+
+// InMainChain reports whether hash is part of the current main chain.
+func (this *BlockIndex) InMainChain(hash common.Uint256) bool {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	node, ok := this.nodeByHash[hash]
+	return ok && node.mainChain
+}
+
+// LookupChildren returns the hashes of every known header whose parent is
+// hash, in the order they were added.
+func (this *BlockIndex) LookupChildren(hash common.Uint256) []common.Uint256 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	children := this.children[hash]
+	result := make([]common.Uint256, len(children))
+	copy(result, children)
+	return result
+}
+
+// BestHeight returns the height of the current main-chain tip, or 0 if the
+// index is empty.
+func (this *BlockIndex) BestHeight() uint32 {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	if this.best == nil {
+		return 0
+	}
+	return this.best.height
+}
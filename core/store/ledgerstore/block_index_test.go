@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The poly network is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The poly network is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the poly network.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/types"
+)
+
+// mkHeader builds a minimal header for index tests. nonce only exists to
+// keep headers at the same (height, PrevBlockHash) from hashing identically
+// when two competing branches fork from the same parent.
+func mkHeader(height uint32, prev common.Uint256, nonce uint64) *types.Header {
+	return &types.Header{
+		Height:        height,
+		PrevBlockHash: prev,
+		ConsensusData: nonce,
+	}
+}
+
+// TestBlockIndexMirrorsSetMainChain verifies BlockIndex has no fork-choice
+// rule of its own: AddHeader alone never changes what is main chain, and
+// InMainChain/BestHeight/Ancestor only ever reflect whatever SetMainChain was
+// told, exactly as BlockStore.SaveBlockHash would tell it.
+func TestBlockIndexMirrorsSetMainChain(t *testing.T) {
+	index := NewBlockIndex()
+
+	genesis := mkHeader(0, common.UINT256_EMPTY, 0)
+	index.AddHeader(genesis)
+	genesisHash := genesis.Hash()
+
+	// chain A: genesis -> a1 -> a2
+	a1 := mkHeader(1, genesisHash, 1)
+	index.AddHeader(a1)
+	a2 := mkHeader(2, a1.Hash(), 1)
+	index.AddHeader(a2)
+
+	// chain B, a competing branch forking at genesis, is also just added to
+	// the index: on its own this must not affect the main chain at all.
+	b1 := mkHeader(1, genesisHash, 2)
+	index.AddHeader(b1)
+	b2 := mkHeader(2, b1.Hash(), 2)
+	index.AddHeader(b2)
+
+	if index.InMainChain(a1.Hash()) || index.InMainChain(b1.Hash()) {
+		t.Fatal("expected AddHeader alone to leave every branch off the main chain")
+	}
+	if index.BestHeight() != 0 {
+		t.Fatalf("expected BestHeight to stay 0 until SetMainChain is called, got %d", index.BestHeight())
+	}
+
+	// the ledger decides chain A is canonical
+	index.SetMainChain(genesisHash, 0)
+	index.SetMainChain(a1.Hash(), 1)
+	index.SetMainChain(a2.Hash(), 2)
+
+	if !index.InMainChain(a2.Hash()) || !index.InMainChain(a1.Hash()) || !index.InMainChain(genesisHash) {
+		t.Fatal("expected chain A to be main chain after SetMainChain")
+	}
+	if index.BestHeight() != 2 {
+		t.Fatalf("expected best height 2, got %d", index.BestHeight())
+	}
+
+	// the ledger later reorgs onto chain B at heights 1 and 2
+	index.SetMainChain(b1.Hash(), 1)
+	index.SetMainChain(b2.Hash(), 2)
+
+	if !index.InMainChain(b1.Hash()) || !index.InMainChain(b2.Hash()) {
+		t.Fatal("expected chain B to become main chain once SetMainChain says so")
+	}
+	if index.InMainChain(a1.Hash()) || index.InMainChain(a2.Hash()) {
+		t.Fatal("expected chain A to be displaced from main chain once SetMainChain moves those heights to chain B")
+	}
+	if !index.InMainChain(genesisHash) {
+		t.Fatal("expected the common ancestor, untouched by the reorg, to remain on the main chain")
+	}
+	if got := index.Ancestor(index.NodeByHash(b2.Hash()), 1); got == nil || got.hash != b1.Hash() {
+		t.Fatal("expected Ancestor(b2, 1) to return b1 after the reorg")
+	}
+	if index.NodeByHeight(1) != index.NodeByHash(b1.Hash()) {
+		t.Fatal("expected NodeByHeight(1) to return chain B's node after the reorg")
+	}
+}
+
+func TestBlockIndexOutOfOrderAttachment(t *testing.T) {
+	index := NewBlockIndex()
+
+	genesis := mkHeader(0, common.UINT256_EMPTY, 0)
+	genesisHash := genesis.Hash()
+	index.AddHeader(genesis)
+	index.SetMainChain(genesisHash, 0)
+
+	h1 := mkHeader(1, genesisHash, 9)
+	h2 := mkHeader(2, h1.Hash(), 9)
+
+	// h2 arrives before its parent h1: it must be parked as an orphan, not
+	// attached with a permanently nil parent.
+	if node := index.AddHeader(h2); node != nil {
+		t.Fatal("expected out-of-order header to be parked as an orphan")
+	}
+	if index.NodeByHash(h2.Hash()) != nil {
+		t.Fatal("orphaned header must not be attached to the index yet")
+	}
+
+	index.AddHeader(h1)
+	if index.NodeByHash(h2.Hash()) == nil {
+		t.Fatal("expected orphan to attach once its parent arrived")
+	}
+	if index.NodeByHash(h2.Hash()).parent != index.NodeByHash(h1.Hash()) {
+		t.Fatal("expected orphan's parent pointer to be wired to its real parent after attachment")
+	}
+
+	// attaching does not, by itself, make h1/h2 main chain
+	if index.InMainChain(h1.Hash()) || index.InMainChain(h2.Hash()) {
+		t.Fatal("expected attachment alone to leave h1/h2 off the main chain until SetMainChain says otherwise")
+	}
+	index.SetMainChain(h1.Hash(), 1)
+	index.SetMainChain(h2.Hash(), 2)
+	if !index.InMainChain(h1.Hash()) || !index.InMainChain(h2.Hash()) {
+		t.Fatal("expected SetMainChain to mark the now-attached orphan chain as main chain")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"sort"
 
 	"io"
 
@@ -38,6 +39,17 @@ type BlockStore struct {
 	dbDir       string                     //The path of store file
 	cache       *BlockCache                //The cache of block, if have.
 	store       *leveldbstore.LevelDBStore //block store handler
+	index       *BlockIndex                //In-memory header index for ancestor queries and GetHeader
+
+	pendingHeaders   []*types.Header  //Headers staged by SaveHeader, applied to index on CommitTo
+	pendingMainChain []mainChainEntry //height->hash mappings staged by SaveBlockHash, applied to index on CommitTo
+}
+
+// mainChainEntry is a height->hash mapping staged by SaveBlockHash, applied
+// to the index only once CommitTo confirms the batch was actually written.
+type mainChainEntry struct {
+	hash   common.Uint256
+	height uint32
 }
 
 // NewBlockStore return the block store instance
@@ -60,10 +72,71 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 		enableCache: enableCache,
 		store:       store,
 		cache:       cache,
+		index:       NewBlockIndex(),
+	}
+	if err := blockStore.loadBlockIndex(); err != nil {
+		return nil, fmt.Errorf("loadBlockIndex error %s", err)
 	}
 	return blockStore, nil
 }
 
+// loadBlockIndex replays every stored header into the in-memory BlockIndex,
+// so NodeByHash/Ancestor/GetHeader queries work immediately on startup
+// without waiting for header sync to touch each header again. Headers are
+// sorted by height before being replayed: leveldb iterates DATA_HEADER
+// entries in hash order, which is unrelated to height, and AddHeader parks
+// any child it sees before its parent as an orphan until that parent is
+// added; replaying in height order links each header to its parent directly.
+//
+// It then replays every DATA_BLOCK height->hash entry through SetMainChain,
+// so NodeByHeight/InMainChain/BestHeight mirror the authoritative mapping
+// getBlockHashKey already committed, rather than guessing at it.
+func (this *BlockStore) loadBlockIndex() error {
+	iter := this.store.NewIterator([]byte{byte(scom.DATA_HEADER)})
+	headers := make([]*types.Header, 0)
+	for iter.Next() {
+		source := common.NewZeroCopySource(iter.Value())
+		header := new(types.Header)
+		if err := header.Deserialization(source); err != nil {
+			iter.Release()
+			return fmt.Errorf("header deserialize error %s", err)
+		}
+		headers = append(headers, header)
+	}
+	if err := iter.Error(); err != nil {
+		iter.Release()
+		return err
+	}
+	iter.Release()
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].Height < headers[j].Height
+	})
+	for _, header := range headers {
+		this.index.AddHeader(header)
+	}
+
+	blockIter := this.store.NewIterator([]byte{byte(scom.DATA_BLOCK)})
+	defer blockIter.Release()
+	for blockIter.Next() {
+		key := blockIter.Key()
+		if len(key) != 5 {
+			return fmt.Errorf("loadBlockIndex, malformed DATA_BLOCK key length %d", len(key))
+		}
+		height := binary.LittleEndian.Uint32(key[1:])
+		hash, err := common.Uint256ParseFromBytes(blockIter.Value())
+		if err != nil {
+			return fmt.Errorf("loadBlockIndex, parse block hash error %s", err)
+		}
+		this.index.SetMainChain(hash, height)
+	}
+	return blockIter.Error()
+}
+
+// BlockIndex returns the in-memory header index backing this store.
+func (this *BlockStore) BlockIndex() *BlockIndex {
+	return this.index
+}
+
 // NewBatch start a commit batch
 func (this *BlockStore) NewBatch() {
 	this.store.NewBatch()
@@ -166,7 +239,9 @@ func (this *BlockStore) loadHeaderWithTx(blockHash common.Uint256) (*types.Heade
 	return header, txHashes, nil
 }
 
-// SaveHeader persist block header to store
+// SaveHeader persist block header to store. The in-memory BlockIndex is not
+// updated until the batch is actually committed by CommitTo, so the two
+// never drift if the commit fails.
 func (this *BlockStore) SaveHeader(block *types.Block) error {
 	blockHash := block.Hash()
 	key := this.getHeaderKey(blockHash)
@@ -178,10 +253,13 @@ func (this *BlockStore) SaveHeader(block *types.Block) error {
 		sink.WriteHash(txHash)
 	}
 	this.store.BatchPut(key, sink.Bytes())
+	this.pendingHeaders = append(this.pendingHeaders, block.Header)
 	return nil
 }
 
-// GetHeader return the header specified by block hash
+// GetHeader return the header specified by block hash. Headers already in the
+// in-memory BlockIndex are served from it; only headers the index does not
+// hold (e.g. ones still parked as orphans) are read from the store.
 func (this *BlockStore) GetHeader(blockHash common.Uint256) (*types.Header, error) {
 	if this.enableCache {
 		block := this.cache.GetBlock(blockHash)
@@ -189,6 +267,9 @@ func (this *BlockStore) GetHeader(blockHash common.Uint256) (*types.Header, erro
 			return block.Header, nil
 		}
 	}
+	if node := this.index.NodeByHash(blockHash); node != nil && node.header != nil {
+		return node.header, nil
+	}
 	return this.loadHeader(blockHash)
 }
 
@@ -237,7 +318,10 @@ func (this *BlockStore) SaveCurrentBlock(height uint32, blockHash common.Uint256
 	return nil
 }
 
-// GetBlockHash return block hash by block height
+// GetBlockHash return block hash by block height. This always answers from
+// getBlockHashKey, the authoritative height->hash mapping SaveBlockHash
+// writes: the in-memory BlockIndex has no fork-choice rule of its own and
+// must never shadow it (see BlockIndex.SetMainChain).
 func (this *BlockStore) GetBlockHash(height uint32) (common.Uint256, error) {
 	key := this.getBlockHashKey(height)
 	value, err := this.store.Get(key)
@@ -251,10 +335,14 @@ func (this *BlockStore) GetBlockHash(height uint32) (common.Uint256, error) {
 	return blockHash, nil
 }
 
-// SaveBlockHash persist block height and block hash to store
+// SaveBlockHash persist block height and block hash to store. The in-memory
+// BlockIndex is not updated until the batch is actually committed by
+// CommitTo, mirroring SaveHeader/pendingHeaders, so the two never drift if
+// the commit fails.
 func (this *BlockStore) SaveBlockHash(height uint32, blockHash common.Uint256) {
 	key := this.getBlockHashKey(height)
 	this.store.BatchPut(key, blockHash.ToArray())
+	this.pendingMainChain = append(this.pendingMainChain, mainChainEntry{hash: blockHash, height: height})
 }
 
 // SaveTransaction persist transaction to store
@@ -370,9 +458,22 @@ func (this *BlockStore) ClearAll() error {
 	return this.CommitTo()
 }
 
-// CommitTo commit the batch to store
+// CommitTo commit the batch to store, then applies any headers staged by
+// SaveHeader and any height->hash mappings staged by SaveBlockHash to the
+// in-memory BlockIndex, so it never reflects a batch that failed to commit.
 func (this *BlockStore) CommitTo() error {
-	return this.store.BatchCommit()
+	if err := this.store.BatchCommit(); err != nil {
+		return err
+	}
+	for _, header := range this.pendingHeaders {
+		this.index.AddHeader(header)
+	}
+	this.pendingHeaders = nil
+	for _, entry := range this.pendingMainChain {
+		this.index.SetMainChain(entry.hash, entry.height)
+	}
+	this.pendingMainChain = nil
+	return nil
 }
 
 // Close block store
@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The poly network is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The poly network is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the poly network.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"sync"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/types"
+)
+
+// OrphanManage holds headers whose parent has not been seen yet, and
+// re-attempts attachment as each new parent arrives, so header sync does not
+// have to reject or re-request out-of-order headers.
+type OrphanManage struct {
+	lock sync.Mutex
+	// byParent indexes pending headers by the parent hash they are waiting on.
+	byParent map[common.Uint256][]*types.Header
+}
+
+// NewOrphanManage returns an empty OrphanManage.
+func NewOrphanManage() *OrphanManage {
+	return &OrphanManage{
+		byParent: make(map[common.Uint256][]*types.Header),
+	}
+}
+
+// AddOrphan records header as waiting on its parent to arrive.
+func (this *OrphanManage) AddOrphan(header *types.Header) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.byParent[header.PrevBlockHash] = append(this.byParent[header.PrevBlockHash], header)
+}
+
+// Resolve returns, and forgets, every orphan that was waiting on parentHash.
+// Callers should re-attempt attaching each returned header, which may in
+// turn unblock further orphans via a recursive call keyed on its own hash.
+func (this *OrphanManage) Resolve(parentHash common.Uint256) []*types.Header {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	headers := this.byParent[parentHash]
+	delete(this.byParent, parentHash)
+	return headers
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The poly network is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The poly network is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the poly network.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package synchron
+
+import (
+	"encoding/binary"
+
+	scom "github.com/polynetwork/poly/core/store/common"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+)
+
+// cursorPrefix is a new leveldb prefix, distinct from the ledgerstore ones in
+// core/store/common, reserved for per-chain federation scan cursors.
+const cursorPrefix = byte(0xf0)
+
+// LevelDBCursorStore persists scan cursors in the same leveldb store the
+// node already uses for ledger data, under cursorPrefix.
+type LevelDBCursorStore struct {
+	store *leveldbstore.LevelDBStore
+}
+
+// NewLevelDBCursorStore returns a CursorStore backed by store.
+func NewLevelDBCursorStore(store *leveldbstore.LevelDBStore) *LevelDBCursorStore {
+	return &LevelDBCursorStore{store: store}
+}
+
+func (this *LevelDBCursorStore) key(chainID uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = cursorPrefix
+	binary.LittleEndian.PutUint64(key[1:], chainID)
+	return key
+}
+
+// GetCursor returns the last height scanned for chainID, or 0 if the chain
+// has never been scanned.
+func (this *LevelDBCursorStore) GetCursor(chainID uint64) (uint32, error) {
+	value, err := this.store.Get(this.key(chainID))
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// SaveCursor persists height as the last height scanned for chainID.
+func (this *LevelDBCursorStore) SaveCursor(chainID uint64, height uint32) error {
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, height)
+	return this.store.Put(this.key(chainID), value)
+}
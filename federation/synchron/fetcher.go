@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The poly network is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The poly network is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the poly network.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package synchron turns a poly node into a first-class relayer: for every
+// registered side chain it scans for deposits on the source chain and
+// submits them through the same ImportOuterTransfer path an external relayer
+// would use, instead of only ever reacting to pushed proofs.
+package synchron
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Deposit is a single cross-chain event a SourceFetcher found on chainID,
+// already carrying the SPV/Merkle proof ImportExTransfer needs to verify it.
+type Deposit struct {
+	Height uint32
+	Proof  []byte
+	TxData []byte
+}
+
+// ImportParam is the package-local shape of the EntranceParam ImportExTransfer
+// expects. Keeper and its callers pass this, not crosscommon.EntranceParam
+// directly, so the synchron package does not import across the
+// github.com/ontio/multi-chain module root; whatever wires TxSubmitter to a
+// real ImportOuterTransfer call is responsible for converting it.
+type ImportParam struct {
+	SourceChainID uint64
+	TargetChainID uint64
+	Height        uint32
+	Proof         []byte
+	TxData        []byte
+}
+
+// SourceFetcher is implemented alongside each ChainHandler (btc, eth, ont,
+// neo, ...) to pull new blocks from the source chain starting at fromHeight,
+// filter for the cross-chain contract address, and build the proof for any
+// deposit found.
+type SourceFetcher interface {
+	// LatestHeight returns the source chain's current height.
+	LatestHeight() (uint32, error)
+	// FetchDeposits returns every deposit found in [fromHeight, toHeight], both
+	// ends included. The keeper passes the height just after its cursor as
+	// fromHeight, so the height it has already scanned is not fetched again.
+	FetchDeposits(fromHeight, toHeight uint32) ([]*Deposit, error)
+}
+
+// EntranceParamOf turns a fetched deposit into the ImportParam TxSubmitter
+// expects, filling in the source/target chain ids the fetcher was registered
+// under.
+func EntranceParamOf(sourceChainID, targetChainID uint64, deposit *Deposit) *ImportParam {
+	return &ImportParam{
+		SourceChainID: sourceChainID,
+		TargetChainID: targetChainID,
+		Height:        deposit.Height,
+		Proof:         deposit.Proof,
+		TxData:        deposit.TxData,
+	}
+}
+
+var (
+	fetcherLock sync.RWMutex
+	fetchers    = make(map[uint64]SourceFetcher)
+)
+
+// RegisterSourceFetcher registers the SourceFetcher that Keeper uses to scan
+// chainID. Like crosscommon.RegisterHandler, it is meant to be called from a
+// package init() so a new chain is onboarded by adding a fetcher, not by
+// editing the keeper.
+func RegisterSourceFetcher(chainID uint64, fetcher SourceFetcher) {
+	fetcherLock.Lock()
+	defer fetcherLock.Unlock()
+	fetchers[chainID] = fetcher
+}
+
+func getSourceFetcher(chainID uint64) (SourceFetcher, error) {
+	fetcherLock.RLock()
+	defer fetcherLock.RUnlock()
+	fetcher, ok := fetchers[chainID]
+	if !ok {
+		return nil, fmt.Errorf("synchron: no SourceFetcher registered for chainid:%d", chainID)
+	}
+	return fetcher, nil
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The poly network is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The poly network is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the poly network.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package synchron
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/polynetwork/poly/common/log"
+)
+
+const (
+	minRetryDelay = time.Second
+	maxRetryDelay = time.Minute
+	scanBatch     = uint32(64)
+)
+
+// TxSubmitter is how a Keeper hands a fetched deposit to the poly node, the
+// same way an external relayer's ImportOuterTransfer transaction would.
+// Kept as an interface so the keeper does not depend on the node's
+// transaction/actor plumbing directly.
+type TxSubmitter interface {
+	Submit(param *ImportParam) error
+}
+
+// CursorStore persists, per source chain, the height the keeper has already
+// scanned up to, so a restart resumes instead of rescanning from genesis.
+type CursorStore interface {
+	GetCursor(chainID uint64) (uint32, error)
+	SaveCursor(chainID uint64, height uint32) error
+}
+
+// Metrics receives a callback after every FetchDeposits call, successful or
+// not, so operators can alert on a chain falling behind or retrying
+// repeatedly. Scans that stop earlier (cursor or LatestHeight errors, or no
+// new heights) produce no callback.
+type Metrics interface {
+	OnScan(chainID uint64, fromHeight, toHeight uint32, deposits int, err error)
+}
+
+// Keeper runs one scanning goroutine per registered side chain, pulling
+// deposits via that chain's SourceFetcher and submitting them through
+// TxSubmitter, so poly nodes can relay cross-chain deposits themselves
+// instead of only accepting proofs pushed by an external relayer.
+type Keeper struct {
+	cursors   CursorStore
+	submitter TxSubmitter
+	metrics   Metrics
+
+	lock    sync.Mutex
+	running map[uint64]chan struct{}
+}
+
+// NewKeeper returns a Keeper that persists scan cursors in cursors and
+// submits fetched deposits through submitter. metrics may be nil.
+func NewKeeper(cursors CursorStore, submitter TxSubmitter, metrics Metrics) *Keeper {
+	return &Keeper{
+		cursors:   cursors,
+		submitter: submitter,
+		metrics:   metrics,
+		running:   make(map[uint64]chan struct{}),
+	}
+}
+
+// Start launches the scanning goroutine for chainID/targetChainID if it is
+// not already running. It is idempotent so side_chain_manager registration
+// can call it every time a chain is (re-)registered.
+func (this *Keeper) Start(chainID, targetChainID uint64) error {
+	if _, err := getSourceFetcher(chainID); err != nil {
+		return err
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if _, ok := this.running[chainID]; ok {
+		return nil
+	}
+	stop := make(chan struct{})
+	this.running[chainID] = stop
+	go this.run(chainID, targetChainID, stop)
+	return nil
+}
+
+// Stop signals the scanning goroutine for chainID to exit.
+func (this *Keeper) Stop(chainID uint64) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if stop, ok := this.running[chainID]; ok {
+		close(stop)
+		delete(this.running, chainID)
+	}
+}
+
+func (this *Keeper) run(chainID, targetChainID uint64, stop chan struct{}) {
+	delay := minRetryDelay
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		advanced, err := this.scanOnce(chainID, targetChainID)
+		if err != nil {
+			log.Errorf("synchron: chain %d scan error: %v, retrying in %s", chainID, err, delay)
+			select {
+			case <-stop:
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+			continue
+		}
+		delay = minRetryDelay
+		if !advanced {
+			select {
+			case <-stop:
+				return
+			case <-time.After(minRetryDelay):
+			}
+		}
+	}
+}
+
+// scanOnce fetches and submits at most scanBatch new heights for chainID,
+// returning whether the cursor advanced.
+func (this *Keeper) scanOnce(chainID, targetChainID uint64) (bool, error) {
+	fetcher, err := getSourceFetcher(chainID)
+	if err != nil {
+		return false, err
+	}
+
+	fromHeight, err := this.cursors.GetCursor(chainID)
+	if err != nil {
+		return false, fmt.Errorf("scanOnce, GetCursor error: %v", err)
+	}
+	latest, err := fetcher.LatestHeight()
+	if err != nil {
+		return false, fmt.Errorf("scanOnce, LatestHeight error: %v", err)
+	}
+	// fromHeight is the last height already scanned, so there is nothing new
+	// once latest has not moved past it.
+	if latest <= fromHeight {
+		return false, nil
+	}
+	toHeight := latest
+	if toHeight-fromHeight > scanBatch {
+		toHeight = fromHeight + scanBatch
+	}
+
+	deposits, err := fetcher.FetchDeposits(fromHeight+1, toHeight)
+	if this.metrics != nil {
+		this.metrics.OnScan(chainID, fromHeight, toHeight, len(deposits), err)
+	}
+	if err != nil {
+		return false, fmt.Errorf("scanOnce, FetchDeposits error: %v", err)
+	}
+
+	for _, deposit := range deposits {
+		if err := this.submitter.Submit(EntranceParamOf(chainID, targetChainID, deposit)); err != nil {
+			return false, fmt.Errorf("scanOnce, Submit error: %v", err)
+		}
+	}
+	if err := this.cursors.SaveCursor(chainID, toHeight); err != nil {
+		return false, fmt.Errorf("scanOnce, SaveCursor error: %v", err)
+	}
+	return toHeight > fromHeight, nil
+}
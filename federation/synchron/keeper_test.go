@@ -0,0 +1,143 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The poly network is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The poly network is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the poly network.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package synchron
+
+import (
+	"testing"
+)
+
+const testChainID = uint64(1)
+
+type fakeCursorStore struct {
+	cursor uint32
+}
+
+func (this *fakeCursorStore) GetCursor(chainID uint64) (uint32, error) {
+	return this.cursor, nil
+}
+
+func (this *fakeCursorStore) SaveCursor(chainID uint64, height uint32) error {
+	this.cursor = height
+	return nil
+}
+
+type fakeFetcher struct {
+	latest  uint32
+	calls   [][2]uint32
+	deposit func(height uint32) *Deposit
+}
+
+func (this *fakeFetcher) LatestHeight() (uint32, error) {
+	return this.latest, nil
+}
+
+func (this *fakeFetcher) FetchDeposits(fromHeight, toHeight uint32) ([]*Deposit, error) {
+	this.calls = append(this.calls, [2]uint32{fromHeight, toHeight})
+	var deposits []*Deposit
+	for h := fromHeight; h <= toHeight; h++ {
+		if this.deposit != nil {
+			if d := this.deposit(h); d != nil {
+				deposits = append(deposits, d)
+			}
+		}
+	}
+	return deposits, nil
+}
+
+type fakeSubmitter struct {
+	submitted []*ImportParam
+}
+
+func (this *fakeSubmitter) Submit(param *ImportParam) error {
+	this.submitted = append(this.submitted, param)
+	return nil
+}
+
+func TestScanOnceExcludesAlreadyScannedHeight(t *testing.T) {
+	fetcher := &fakeFetcher{latest: 10}
+	RegisterSourceFetcher(testChainID, fetcher)
+
+	cursors := &fakeCursorStore{cursor: 5}
+	submitter := &fakeSubmitter{}
+	keeper := NewKeeper(cursors, submitter, nil)
+
+	advanced, err := keeper.scanOnce(testChainID, 2)
+	if err != nil {
+		t.Fatalf("scanOnce error: %v", err)
+	}
+	if !advanced {
+		t.Fatal("expected scanOnce to report progress")
+	}
+	if len(fetcher.calls) != 1 || fetcher.calls[0] != [2]uint32{6, 10} {
+		t.Fatalf("expected FetchDeposits(6, 10) excluding the already-scanned height 5, got %v", fetcher.calls)
+	}
+	if cursors.cursor != 10 {
+		t.Fatalf("expected cursor to advance to 10, got %d", cursors.cursor)
+	}
+}
+
+func TestScanOnceDoesNotRescanBoundaryOnNextCall(t *testing.T) {
+	fetcher := &fakeFetcher{latest: 10}
+	RegisterSourceFetcher(testChainID, fetcher)
+
+	cursors := &fakeCursorStore{cursor: 5}
+	keeper := NewKeeper(cursors, &fakeSubmitter{}, nil)
+
+	if _, err := keeper.scanOnce(testChainID, 2); err != nil {
+		t.Fatalf("first scanOnce error: %v", err)
+	}
+	// latest has not moved: a second pass must neither refetch height 10 nor
+	// busy-loop resubmitting it.
+	advanced, err := keeper.scanOnce(testChainID, 2)
+	if err != nil {
+		t.Fatalf("second scanOnce error: %v", err)
+	}
+	if advanced {
+		t.Fatal("expected scanOnce to report no progress once caught up to the source chain tip")
+	}
+	if len(fetcher.calls) != 1 {
+		t.Fatalf("expected no additional FetchDeposits call once caught up, got calls=%v", fetcher.calls)
+	}
+}
+
+func TestScanOnceSubmitsFetchedDeposits(t *testing.T) {
+	fetcher := &fakeFetcher{
+		latest: 3,
+		deposit: func(height uint32) *Deposit {
+			return &Deposit{Height: height, TxData: []byte{byte(height)}}
+		},
+	}
+	RegisterSourceFetcher(testChainID, fetcher)
+
+	cursors := &fakeCursorStore{cursor: 0}
+	submitter := &fakeSubmitter{}
+	keeper := NewKeeper(cursors, submitter, nil)
+
+	if _, err := keeper.scanOnce(testChainID, 2); err != nil {
+		t.Fatalf("scanOnce error: %v", err)
+	}
+	if len(submitter.submitted) != 3 {
+		t.Fatalf("expected 3 deposits submitted for heights 1-3, got %d", len(submitter.submitted))
+	}
+	for _, param := range submitter.submitted {
+		if param.SourceChainID != testChainID || param.TargetChainID != 2 {
+			t.Fatalf("expected submitted params to carry the source/target chain ids, got %+v", param)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package common
+
+import (
+	"io"
+
+	"github.com/ontio/multi-chain/common"
+	"github.com/ontio/multi-chain/native"
+)
+
+var (
+	errEntranceParamEOF = io.ErrUnexpectedEOF
+	errMakeTxParamEOF   = io.ErrUnexpectedEOF
+)
+
+// ChainHandler is implemented once per source/target chain (btc, eth, ont,
+// neo, ...) and does the chain-specific verification and transaction
+// building that ImportExTransfer, Vote and MultiSign drive generically.
+type ChainHandler interface {
+	MakeDepositProposal(service *native.NativeService) (*MakeTxParam, error)
+	MakeTransaction(service *native.NativeService, param *MakeTxParam) error
+	Vote(service *native.NativeService) (bool, *MakeTxParam, error)
+	MultiSign(service *native.NativeService) error
+	InitRedeemScript(service *native.NativeService) error
+}
+
+// EntranceParam is the contract parameter for ImportOuterTransfer: a relayer
+// submits the source-chain proof/txdata for a cross-chain event so it can be
+// verified and turned into a deposit proposal.
+type EntranceParam struct {
+	SourceChainID  uint64
+	Height         uint32
+	Proof          []byte
+	RelayerAddress []byte
+	Value          []byte
+	TxData         []byte
+	TargetChainID  uint64
+	// Conflicts lists cross-chain transaction ids (see MakeTxParam.TxHash)
+	// that must NOT already be marked done on the poly side. It lets a
+	// relayer atomically supersede a pending swap, e.g. with a corrected
+	// proof for the same source event or a cancel path.
+	Conflicts [][]byte
+}
+
+func (this *EntranceParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.SourceChainID)
+	sink.WriteUint32(this.Height)
+	sink.WriteVarBytes(this.Proof)
+	sink.WriteVarBytes(this.RelayerAddress)
+	sink.WriteVarBytes(this.Value)
+	sink.WriteVarBytes(this.TxData)
+	sink.WriteUint64(this.TargetChainID)
+	sink.WriteVarUint(uint64(len(this.Conflicts)))
+	for _, conflict := range this.Conflicts {
+		sink.WriteVarBytes(conflict)
+	}
+}
+
+func (this *EntranceParam) Deserialization(source *common.ZeroCopySource) error {
+	sourceChainID, eof := source.NextUint64()
+	if eof {
+		return errEntranceParamEOF
+	}
+	height, eof := source.NextUint32()
+	if eof {
+		return errEntranceParamEOF
+	}
+	proof, eof := source.NextVarBytes()
+	if eof {
+		return errEntranceParamEOF
+	}
+	relayerAddress, eof := source.NextVarBytes()
+	if eof {
+		return errEntranceParamEOF
+	}
+	value, eof := source.NextVarBytes()
+	if eof {
+		return errEntranceParamEOF
+	}
+	txData, eof := source.NextVarBytes()
+	if eof {
+		return errEntranceParamEOF
+	}
+	targetChainID, eof := source.NextUint64()
+	if eof {
+		return errEntranceParamEOF
+	}
+	conflictCount, eof := source.NextVarUint()
+	if eof {
+		return errEntranceParamEOF
+	}
+	conflicts := make([][]byte, 0, conflictCount)
+	for i := uint64(0); i < conflictCount; i++ {
+		conflict, eof := source.NextVarBytes()
+		if eof {
+			return errEntranceParamEOF
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	this.SourceChainID = sourceChainID
+	this.Height = height
+	this.Proof = proof
+	this.RelayerAddress = relayerAddress
+	this.Value = value
+	this.TxData = txData
+	this.TargetChainID = targetChainID
+	this.Conflicts = conflicts
+	return nil
+}
+
+// MakeTxParam is what a ChainHandler hands back after it turns a verified
+// source-chain event into a deposit proposal, ready to be relayed onward by
+// the target chain's own handler.
+type MakeTxParam struct {
+	TxHash      []byte
+	FromChainID uint64
+	ToChainID   uint64
+	ToContract  []byte
+	Method      string
+	Args        []byte
+}
+
+func (this *MakeTxParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.TxHash)
+	sink.WriteUint64(this.FromChainID)
+	sink.WriteUint64(this.ToChainID)
+	sink.WriteVarBytes(this.ToContract)
+	sink.WriteString(this.Method)
+	sink.WriteVarBytes(this.Args)
+}
+
+func (this *MakeTxParam) Deserialization(source *common.ZeroCopySource) error {
+	txHash, eof := source.NextVarBytes()
+	if eof {
+		return errMakeTxParamEOF
+	}
+	fromChainID, eof := source.NextUint64()
+	if eof {
+		return errMakeTxParamEOF
+	}
+	toChainID, eof := source.NextUint64()
+	if eof {
+		return errMakeTxParamEOF
+	}
+	toContract, eof := source.NextVarBytes()
+	if eof {
+		return errMakeTxParamEOF
+	}
+	method, eof := source.NextString()
+	if eof {
+		return errMakeTxParamEOF
+	}
+	args, eof := source.NextVarBytes()
+	if eof {
+		return errMakeTxParamEOF
+	}
+	this.TxHash = txHash
+	this.FromChainID = fromChainID
+	this.ToChainID = toChainID
+	this.ToContract = toContract
+	this.Method = method
+	this.Args = args
+	return nil
+}
@@ -0,0 +1,137 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ontio/multi-chain/native"
+)
+
+var (
+	doneTxPrefix       = []byte("CrossChainDoneTx")
+	supersededTxPrefix = []byte("CrossChainSupersededTx")
+)
+
+func doneTxKey(id []byte) []byte {
+	key := make([]byte, 0, len(doneTxPrefix)+len(id))
+	key = append(key, doneTxPrefix...)
+	key = append(key, id...)
+	return key
+}
+
+func supersededTxKey(id []byte) []byte {
+	key := make([]byte, 0, len(supersededTxPrefix)+len(id))
+	key = append(key, supersededTxPrefix...)
+	key = append(key, id...)
+	return key
+}
+
+// DoneTxStore is the dedupe store ImportExTransfer checks a cross-chain
+// transaction id against: once an id is marked done here, no later proposal
+// for that same id is ever accepted again. Listing an id under Conflicts
+// marks that id superseded immediately, even if it has not been seen yet, so
+// whichever of two conflicting proofs is processed first permanently locks
+// out the other regardless of arrival order. CheckConflicts is written
+// against this interface instead of *native.NativeService directly so it can
+// be unit tested without one.
+type DoneTxStore interface {
+	CheckDoneTx(id []byte) (bool, error)
+	PutDoneTx(id []byte) error
+	// CheckSuperseded reports whether id has been named as a conflict by some
+	// other proposal, regardless of whether that proposal or id itself has
+	// been committed yet.
+	CheckSuperseded(id []byte) (bool, error)
+	// MarkSuperseded records that id must never be committed because by []byte
+	// named it as a conflict.
+	MarkSuperseded(id []byte, by []byte) error
+}
+
+type nativeDoneTxStore struct {
+	native *native.NativeService
+}
+
+// NewNativeDoneTxStore adapts a NativeService's cache db to DoneTxStore.
+func NewNativeDoneTxStore(native *native.NativeService) DoneTxStore {
+	return nativeDoneTxStore{native: native}
+}
+
+func (this nativeDoneTxStore) CheckDoneTx(id []byte) (bool, error) {
+	value, err := this.native.GetCacheDB().Get(doneTxKey(id))
+	if err != nil {
+		return false, fmt.Errorf("CheckDoneTx, get done tx error: %v", err)
+	}
+	return len(value) != 0, nil
+}
+
+func (this nativeDoneTxStore) PutDoneTx(id []byte) error {
+	return this.native.GetCacheDB().Put(doneTxKey(id), []byte{1})
+}
+
+func (this nativeDoneTxStore) CheckSuperseded(id []byte) (bool, error) {
+	value, err := this.native.GetCacheDB().Get(supersededTxKey(id))
+	if err != nil {
+		return false, fmt.Errorf("CheckSuperseded, get superseded tx error: %v", err)
+	}
+	return len(value) != 0, nil
+}
+
+func (this nativeDoneTxStore) MarkSuperseded(id []byte, by []byte) error {
+	return this.native.GetCacheDB().Put(supersededTxKey(id), by)
+}
+
+// CheckDoneTx reports whether id has already been committed as a processed
+// cross-chain transaction, so a conflicting or replayed proposal can be
+// rejected before it is applied.
+func CheckDoneTx(native *native.NativeService, id []byte) (bool, error) {
+	return NewNativeDoneTxStore(native).CheckDoneTx(id)
+}
+
+// PutDoneTx marks id as committed, so any later proposal that lists it in
+// EntranceParam.Conflicts is rejected.
+func PutDoneTx(native *native.NativeService, id []byte) error {
+	return NewNativeDoneTxStore(native).PutDoneTx(id)
+}
+
+// CheckConflicts is the single place ImportExTransfer relies on for
+// double-spend protection: it rejects id if it has already been committed
+// (the same id being imported twice) or superseded (some other proposal
+// already named id as a conflict, whether or not that proposal has itself
+// been committed yet), rejects id if it lists itself as a conflict, rejects
+// id if any listed conflict is already committed, and otherwise marks every
+// listed conflict superseded before committing id. Registration is therefore
+// bidirectional: whichever of two conflicting proofs is processed first wins
+// permanently, regardless of the order the pair arrives in.
+func CheckConflicts(store DoneTxStore, id []byte, conflicts [][]byte) error {
+	done, err := store.CheckDoneTx(id)
+	if err != nil {
+		return fmt.Errorf("CheckConflicts, CheckDoneTx error: %v", err)
+	}
+	if done {
+		return fmt.Errorf("CheckConflicts, %x has already been committed", id)
+	}
+	superseded, err := store.CheckSuperseded(id)
+	if err != nil {
+		return fmt.Errorf("CheckConflicts, CheckSuperseded error: %v", err)
+	}
+	if superseded {
+		return fmt.Errorf("CheckConflicts, %x has already been superseded by a conflicting transaction", id)
+	}
+	for _, conflict := range conflicts {
+		if bytes.Equal(conflict, id) {
+			return fmt.Errorf("CheckConflicts, a transaction cannot list itself as a conflict")
+		}
+		conflictDone, err := store.CheckDoneTx(conflict)
+		if err != nil {
+			return fmt.Errorf("CheckConflicts, CheckDoneTx error: %v", err)
+		}
+		if conflictDone {
+			return fmt.Errorf("CheckConflicts, conflict %x has already been committed", conflict)
+		}
+	}
+	for _, conflict := range conflicts {
+		if err := store.MarkSuperseded(conflict, id); err != nil {
+			return fmt.Errorf("CheckConflicts, MarkSuperseded error: %v", err)
+		}
+	}
+	return store.PutDoneTx(id)
+}
@@ -0,0 +1,87 @@
+package common
+
+import "testing"
+
+// fakeDoneTxStore is an in-memory DoneTxStore used to exercise CheckConflicts
+// without a real native.NativeService/CacheDB.
+type fakeDoneTxStore struct {
+	done       map[string]bool
+	superseded map[string]bool
+}
+
+func newFakeDoneTxStore() *fakeDoneTxStore {
+	return &fakeDoneTxStore{done: make(map[string]bool), superseded: make(map[string]bool)}
+}
+
+func (this *fakeDoneTxStore) CheckDoneTx(id []byte) (bool, error) {
+	return this.done[string(id)], nil
+}
+
+func (this *fakeDoneTxStore) PutDoneTx(id []byte) error {
+	this.done[string(id)] = true
+	return nil
+}
+
+func (this *fakeDoneTxStore) CheckSuperseded(id []byte) (bool, error) {
+	return this.superseded[string(id)], nil
+}
+
+func (this *fakeDoneTxStore) MarkSuperseded(id []byte, by []byte) error {
+	this.superseded[string(id)] = true
+	return nil
+}
+
+func TestCheckConflictsAcceptsFreshTx(t *testing.T) {
+	store := newFakeDoneTxStore()
+	if err := CheckConflicts(store, []byte("tx-a"), nil); err != nil {
+		t.Fatalf("expected a fresh tx with no conflicts to be accepted: %v", err)
+	}
+	done, err := store.CheckDoneTx([]byte("tx-a"))
+	if err != nil || !done {
+		t.Fatal("expected tx-a to be marked done after a successful check")
+	}
+}
+
+func TestCheckConflictsRejectsReplay(t *testing.T) {
+	store := newFakeDoneTxStore()
+	if err := CheckConflicts(store, []byte("tx-a"), nil); err != nil {
+		t.Fatalf("first commit of tx-a should succeed: %v", err)
+	}
+	if err := CheckConflicts(store, []byte("tx-a"), nil); err == nil {
+		t.Fatal("expected replaying an already-committed tx id to be rejected")
+	}
+}
+
+func TestCheckConflictsRejectsSelfConflict(t *testing.T) {
+	store := newFakeDoneTxStore()
+	if err := CheckConflicts(store, []byte("tx-a"), [][]byte{[]byte("tx-a")}); err == nil {
+		t.Fatal("expected a tx that lists itself as a conflict to be rejected")
+	}
+}
+
+func TestCheckConflictsRejectsAlreadyCommittedConflict(t *testing.T) {
+	store := newFakeDoneTxStore()
+	if err := CheckConflicts(store, []byte("tx-a"), nil); err != nil {
+		t.Fatalf("committing tx-a should succeed: %v", err)
+	}
+	// tx-b arrives later and lists the already-committed tx-a as a conflict:
+	// it must be rejected so the two proposals can't both take effect.
+	if err := CheckConflicts(store, []byte("tx-b"), [][]byte{[]byte("tx-a")}); err == nil {
+		t.Fatal("expected a tx conflicting with an already-committed one to be rejected")
+	}
+}
+
+func TestCheckConflictsRejectsOriginalThatArrivesAfterASupersedingProof(t *testing.T) {
+	store := newFakeDoneTxStore()
+	// tx-b is submitted first, listing tx-a (not yet seen) as a conflict -
+	// e.g. a corrected proof or cancel path for the same source event as tx-a.
+	if err := CheckConflicts(store, []byte("tx-b"), [][]byte{[]byte("tx-a")}); err != nil {
+		t.Fatalf("expected tx-b to be accepted since tx-a is not yet committed: %v", err)
+	}
+	// tx-a now arrives on its own, without listing tx-b as a conflict (the
+	// relayer submitting the original proof has no way to know about tx-b).
+	// It must still be rejected: tx-b already superseded it.
+	if err := CheckConflicts(store, []byte("tx-a"), nil); err == nil {
+		t.Fatal("expected tx-a to be rejected: it was already superseded by tx-b, regardless of arrival order")
+	}
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+)
+
+// HandlerCaps describes the optional behaviors a registered ChainHandler
+// needs from the generic entrypoints, so chain ID is never branched on
+// directly outside of the registry itself.
+type HandlerCaps struct {
+	// NeedsTargetTx means a successful deposit proposal for this chain must
+	// be relayed straight on to the target chain handler's MakeTransaction.
+	NeedsTargetTx bool
+	// VoteGated means deposits for this chain are only finalized through the
+	// separate Vote entrypoint, not by MakeDepositProposal alone.
+	VoteGated bool
+	// RedeemScript means this chain requires InitRedeemScript to have been
+	// called before deposits can be processed.
+	RedeemScript bool
+}
+
+// HandlerFactory builds a fresh ChainHandler instance for a chain.
+type HandlerFactory func() ChainHandler
+
+type handlerRegistration struct {
+	factory HandlerFactory
+	caps    HandlerCaps
+}
+
+var (
+	handlerRegistryLock sync.RWMutex
+	handlerRegistry     = make(map[uint64]handlerRegistration)
+)
+
+// RegisterHandler registers the factory and capabilities for chainID. It is
+// meant to be called from a package init() so that supporting a new chain
+// only requires a new handler package, not an edit to cross_chain_manager.
+func RegisterHandler(chainID uint64, factory HandlerFactory, caps HandlerCaps) {
+	handlerRegistryLock.Lock()
+	defer handlerRegistryLock.Unlock()
+	handlerRegistry[chainID] = handlerRegistration{factory: factory, caps: caps}
+}
+
+// GetHandler returns a new ChainHandler for chainID along with the
+// capabilities it was registered with.
+func GetHandler(chainID uint64) (ChainHandler, HandlerCaps, error) {
+	handlerRegistryLock.RLock()
+	reg, ok := handlerRegistry[chainID]
+	handlerRegistryLock.RUnlock()
+	if !ok {
+		return nil, HandlerCaps{}, fmt.Errorf("not a supported chainid:%d", chainID)
+	}
+	return reg.factory(), reg.caps, nil
+}
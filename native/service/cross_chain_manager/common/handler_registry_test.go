@@ -0,0 +1,123 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/ontio/multi-chain/native"
+)
+
+// dummyHandler is a minimal ChainHandler used to exercise the registry and
+// the generic import path without touching cross_chain_manager or any real
+// chain package (which would create an import cycle back into this one).
+type dummyHandler struct {
+	toChainID   uint64
+	txHash      []byte
+	targetCalls *[]*MakeTxParam
+}
+
+func (this dummyHandler) MakeDepositProposal(service *native.NativeService) (*MakeTxParam, error) {
+	return &MakeTxParam{TxHash: this.txHash, ToChainID: this.toChainID}, nil
+}
+
+func (this dummyHandler) MakeTransaction(service *native.NativeService, param *MakeTxParam) error {
+	if this.targetCalls != nil {
+		*this.targetCalls = append(*this.targetCalls, param)
+	}
+	return nil
+}
+
+func (this dummyHandler) Vote(service *native.NativeService) (bool, *MakeTxParam, error) {
+	return false, nil, nil
+}
+
+func (this dummyHandler) MultiSign(service *native.NativeService) error { return nil }
+
+func (this dummyHandler) InitRedeemScript(service *native.NativeService) error { return nil }
+
+// dummySourceChainID/dummyTargetChainID are picked far outside the range of
+// any real registered chain id so these tests cannot collide with
+// btc/eth/ont/neo's registrations.
+const (
+	dummyChainID       = uint64(0xdeadbeef)
+	dummySourceChainID = uint64(0xdeadbeec)
+	dummyTargetChainID = uint64(0xdeadbeed)
+)
+
+func TestRegisterHandlerAndGetHandler(t *testing.T) {
+	RegisterHandler(dummyChainID, func() ChainHandler { return dummyHandler{toChainID: 42, txHash: []byte("dummy-tx")} },
+		HandlerCaps{NeedsTargetTx: true})
+
+	handler, caps, err := GetHandler(dummyChainID)
+	if err != nil {
+		t.Fatalf("expected registered dummy chain to be found: %v", err)
+	}
+	if !caps.NeedsTargetTx {
+		t.Fatal("expected the registered caps to be returned unchanged")
+	}
+
+	txParam, err := handler.MakeDepositProposal(nil)
+	if err != nil {
+		t.Fatalf("MakeDepositProposal error: %v", err)
+	}
+	if txParam.ToChainID != 42 {
+		t.Fatalf("expected dummy handler's proposal to come back through the registry, got ToChainID=%d", txParam.ToChainID)
+	}
+}
+
+func TestGetHandlerUnregistered(t *testing.T) {
+	const unregisteredChainID = uint64(0xfeedface)
+	if _, _, err := GetHandler(unregisteredChainID); err == nil {
+		t.Fatal("expected an unregistered chainid to return an error")
+	}
+}
+
+// TestGenericImportPathAppliesCapsAndConflictChecking drives the same
+// sequence ImportExTransfer does - GetHandler, MakeDepositProposal,
+// CheckConflicts, caps-gated GetHandler/MakeTransaction on the target chain -
+// instead of calling MakeDepositProposal on its own. It lives here rather
+// than in cross_chain_manager because importing that package back into
+// common would be a cycle; this is as close to the real generic import path
+// as a common-only test can get.
+func TestGenericImportPathAppliesCapsAndConflictChecking(t *testing.T) {
+	var targetCalls []*MakeTxParam
+	RegisterHandler(dummySourceChainID,
+		func() ChainHandler { return dummyHandler{toChainID: dummyTargetChainID, txHash: []byte("generic-import-tx")} },
+		HandlerCaps{NeedsTargetTx: true})
+	RegisterHandler(dummyTargetChainID,
+		func() ChainHandler { return dummyHandler{targetCalls: &targetCalls} },
+		HandlerCaps{})
+
+	store := newFakeDoneTxStore()
+
+	handler, caps, err := GetHandler(dummySourceChainID)
+	if err != nil {
+		t.Fatalf("GetHandler(source) error: %v", err)
+	}
+	txParam, err := handler.MakeDepositProposal(nil)
+	if err != nil {
+		t.Fatalf("MakeDepositProposal error: %v", err)
+	}
+	if err := CheckConflicts(store, txParam.TxHash, nil); err != nil {
+		t.Fatalf("expected a fresh deposit to pass CheckConflicts: %v", err)
+	}
+	if !caps.NeedsTargetTx {
+		t.Fatal("expected the source chain's caps to gate relaying onward, as ImportExTransfer checks")
+	}
+
+	targetHandler, _, err := GetHandler(txParam.ToChainID)
+	if err != nil {
+		t.Fatalf("GetHandler(target) error: %v", err)
+	}
+	if err := targetHandler.MakeTransaction(nil, txParam); err != nil {
+		t.Fatalf("MakeTransaction error: %v", err)
+	}
+	if len(targetCalls) != 1 || targetCalls[0] != txParam {
+		t.Fatalf("expected the target chain handler to receive the proposal built by the source handler, got %v", targetCalls)
+	}
+
+	// a relayer resubmitting the same deposit must now be rejected, exactly
+	// as ImportExTransfer's double-spend guard requires.
+	if err := CheckConflicts(store, txParam.TxHash, nil); err == nil {
+		t.Fatal("expected CheckConflicts to reject replaying an already-committed deposit")
+	}
+}
@@ -31,19 +31,24 @@ func RegisterCrossChainManagerContract(native *native.NativeService) {
 	native.Register(MULTI_SIGN, MultiSign)
 }
 
+func init() {
+	crosscommon.RegisterHandler(utils.BTC_CHAIN_ID, func() crosscommon.ChainHandler { return btc.NewBTCHandler() },
+		crosscommon.HandlerCaps{VoteGated: true, RedeemScript: true})
+	crosscommon.RegisterHandler(utils.ETH_CHAIN_ID, func() crosscommon.ChainHandler { return eth.NewETHHandler() },
+		crosscommon.HandlerCaps{NeedsTargetTx: true})
+	crosscommon.RegisterHandler(utils.ONT_CHAIN_ID, func() crosscommon.ChainHandler { return ont.NewONTHandler() },
+		crosscommon.HandlerCaps{NeedsTargetTx: true})
+	crosscommon.RegisterHandler(utils.NEO_CHAIN_ID, func() crosscommon.ChainHandler { return neo.NewNEOHandler() },
+		crosscommon.HandlerCaps{})
+}
+
+// GetChainHandler returns the handler registered for chainid. Handlers are
+// added via crosscommon.RegisterHandler at init time instead of being
+// switched on here, so a new source/target chain never requires editing this
+// package.
 func GetChainHandler(chainid uint64) (crosscommon.ChainHandler, error) {
-	switch chainid {
-	case utils.BTC_CHAIN_ID:
-		return btc.NewBTCHandler(), nil
-	case utils.ETH_CHAIN_ID:
-		return eth.NewETHHandler(), nil
-	case utils.ONT_CHAIN_ID:
-		return ont.NewONTHandler(), nil
-	case utils.NEO_CHAIN_ID:
-		return neo.NewNEOHandler(), nil
-	default:
-		return nil, fmt.Errorf("not a supported chainid:%d", chainid)
-	}
+	handler, _, err := crosscommon.GetHandler(chainid)
+	return handler, err
 }
 
 func ImportExTransfer(native *native.NativeService) ([]byte, error) {
@@ -70,41 +75,46 @@ func ImportExTransfer(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, side chain is not registered")
 	}
 
-	handler, err := GetChainHandler(chainID)
+	handler, caps, err := crosscommon.GetHandler(chainID)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
 	//1. verify tx
-	if chainID == 2 || chainID == 3 {
-		txParam, err := handler.MakeDepositProposal(native)
-		if err != nil {
-			return utils.BYTE_FALSE, err
-		}
-
-		//2. make target chain tx
-		targetid := txParam.ToChainID
+	txParam, err := handler.MakeDepositProposal(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
 
-		//check if chainid exist
-		sideChain, err = side_chain_manager.GetSideChain(native, targetid)
-		if err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, side_chain_manager.GetSideChain error: %v", err)
-		}
-		if sideChain.ChainId != targetid {
-			return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, targetid chain is not registered")
-		}
+	//1.5 reject if this exact transaction was already processed, or if it
+	//conflicts with an already-committed one, so a relayer cannot replay an
+	//import or submit two different proofs of the same source event and have
+	//both processed
+	if err := crosscommon.CheckConflicts(crosscommon.NewNativeDoneTxStore(native), txParam.TxHash, params.Conflicts); err != nil {
+		return utils.BYTE_FALSE, err
+	}
 
-		targetHandler, err := GetChainHandler(targetid)
-		if err != nil {
-			return utils.BYTE_FALSE, err
-		}
-		//NOTE, you need to store the tx in this
-		err = targetHandler.MakeTransaction(native, txParam)
-		if err != nil {
-			return utils.BYTE_FALSE, err
-		}
+	if !caps.NeedsTargetTx {
 		return utils.BYTE_TRUE, nil
 	}
-	_, err = handler.MakeDepositProposal(native)
+
+	//2. make target chain tx
+	targetid := txParam.ToChainID
+
+	//check if chainid exist
+	sideChain, err = side_chain_manager.GetSideChain(native, targetid)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain.ChainId != targetid {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, targetid chain is not registered")
+	}
+
+	targetHandler, err := GetChainHandler(targetid)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	//NOTE, you need to store the tx in this
+	err = targetHandler.MakeTransaction(native, txParam)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
@@ -112,7 +122,13 @@ func ImportExTransfer(native *native.NativeService) ([]byte, error) {
 }
 
 func Vote(native *native.NativeService) ([]byte, error) {
-	handler := btc.NewBTCHandler()
+	handler, caps, err := crosscommon.GetHandler(utils.BTC_CHAIN_ID)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if !caps.VoteGated {
+		return utils.BYTE_FALSE, fmt.Errorf("Vote, chain %d is not vote-gated", utils.BTC_CHAIN_ID)
+	}
 
 	//1. vote
 	ok, txParam, err := handler.Vote(native)
@@ -147,10 +163,16 @@ func Vote(native *native.NativeService) ([]byte, error) {
 }
 
 func MultiSign(native *native.NativeService) ([]byte, error) {
-	handler := btc.NewBTCHandler()
+	handler, caps, err := crosscommon.GetHandler(utils.BTC_CHAIN_ID)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if !caps.VoteGated {
+		return utils.BYTE_FALSE, fmt.Errorf("MultiSign, chain %d is not vote-gated", utils.BTC_CHAIN_ID)
+	}
 
 	//1. multi sign
-	err := handler.MultiSign(native)
+	err = handler.MultiSign(native)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
@@ -158,10 +180,16 @@ func MultiSign(native *native.NativeService) ([]byte, error) {
 }
 
 func InitRedeemScript(native *native.NativeService) ([]byte, error) {
-	handler := btc.NewBTCHandler()
+	handler, caps, err := crosscommon.GetHandler(utils.BTC_CHAIN_ID)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if !caps.RedeemScript {
+		return utils.BYTE_FALSE, fmt.Errorf("InitRedeemScript, chain %d does not use a redeem script", utils.BTC_CHAIN_ID)
+	}
 
-	//1. multi sign
-	err := handler.InitRedeemScript(native)
+	//1. init redeem script
+	err = handler.InitRedeemScript(native)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/ontio/multi-chain/common"
+	"github.com/ontio/ontology-crypto/keypair"
+)
+
+// SyncCheckpointHeaderParam seeds header sync at a trusted height instead of
+// requiring the full header chain from genesis. CheckpointHeader must be
+// signed off by Bookkeepers, the current poly consensus set at the time the
+// checkpoint was taken, so a new side chain can be onboarded (or a corrupted
+// header chain recovered) without replaying millions of headers.
+type SyncCheckpointHeaderParam struct {
+	ChainID          uint64
+	CheckpointHeader []byte
+	Bookkeepers      []keypair.PublicKey
+	Address          common.Address
+}
+
+func (this *SyncCheckpointHeaderParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.ChainID)
+	sink.WriteVarBytes(this.CheckpointHeader)
+	sink.WriteVarUint(uint64(len(this.Bookkeepers)))
+	for _, bookkeeper := range this.Bookkeepers {
+		sink.WriteVarBytes(keypair.SerializePublicKey(bookkeeper))
+	}
+	sink.WriteAddress(this.Address)
+}
+
+func (this *SyncCheckpointHeaderParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("SyncCheckpointHeaderParam deserialize ChainID error")
+	}
+	checkpointHeader, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("SyncCheckpointHeaderParam deserialize CheckpointHeader error")
+	}
+	bookkeeperCount, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("SyncCheckpointHeaderParam deserialize Bookkeepers count error")
+	}
+	bookkeepers := make([]keypair.PublicKey, 0, bookkeeperCount)
+	for i := uint64(0); i < bookkeeperCount; i++ {
+		raw, eof := source.NextVarBytes()
+		if eof {
+			return fmt.Errorf("SyncCheckpointHeaderParam deserialize Bookkeepers error")
+		}
+		pubKey, err := keypair.DeserializePublicKey(raw)
+		if err != nil {
+			return fmt.Errorf("SyncCheckpointHeaderParam deserialize public key error: %v", err)
+		}
+		bookkeepers = append(bookkeepers, pubKey)
+	}
+	address, eof := source.NextAddress()
+	if eof {
+		return fmt.Errorf("SyncCheckpointHeaderParam deserialize Address error")
+	}
+	this.ChainID = chainID
+	this.CheckpointHeader = checkpointHeader
+	this.Bookkeepers = bookkeepers
+	this.Address = address
+	return nil
+}
@@ -0,0 +1,50 @@
+package header_sync
+
+import (
+	"fmt"
+
+	"github.com/ontio/multi-chain/common"
+	"github.com/ontio/multi-chain/native"
+	"github.com/ontio/multi-chain/native/service/header_sync/ont"
+	"github.com/ontio/multi-chain/native/service/utils"
+)
+
+const (
+	SYNC_CHECKPOINT_HEADER_NAME = "SyncCheckpointHeader"
+)
+
+func RegisterHeaderSyncContract(native *native.NativeService) {
+	native.Register(SYNC_CHECKPOINT_HEADER_NAME, SyncCheckpointHeader)
+}
+
+// checkpointHandler is implemented by the per-chain header_sync handler
+// (ont, and eth/neo once they grow the same method) that SyncCheckpointHeader
+// dispatches to.
+type checkpointHandler interface {
+	SyncCheckpointHeader(native *native.NativeService) error
+}
+
+// SyncCheckpointHeader dispatches the governance-submitted checkpoint to the
+// handler for params.ChainID. Only ONT is wired up in this tree; ETH/NEO need
+// the same SyncCheckpointHeader method added to their header_sync handlers
+// before they can be added to the switch below.
+func SyncCheckpointHeader(native *native.NativeService) ([]byte, error) {
+	source := common.NewZeroCopySource(native.GetInput())
+	chainID, eof := source.NextUint64()
+	if eof {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncCheckpointHeader, peek chainid error")
+	}
+
+	var handler checkpointHandler
+	switch chainID {
+	case utils.ONT_CHAIN_ID:
+		handler = ont.NewONTHandler()
+	default:
+		return utils.BYTE_FALSE, fmt.Errorf("SyncCheckpointHeader, not a supported chainid:%d", chainID)
+	}
+
+	if err := handler.SyncCheckpointHeader(native); err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	return utils.BYTE_TRUE, nil
+}
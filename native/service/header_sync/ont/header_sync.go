@@ -26,8 +26,10 @@ import (
 	"github.com/ontio/multi-chain/core/genesis"
 	"github.com/ontio/multi-chain/core/types"
 	"github.com/ontio/multi-chain/native"
+	"github.com/ontio/multi-chain/native/service/governance/node_manager"
 	hscommon "github.com/ontio/multi-chain/native/service/header_sync/common"
 	"github.com/ontio/multi-chain/native/service/utils"
+	"github.com/ontio/ontology-crypto/keypair"
 	otypes "github.com/ontio/ontology/core/types"
 )
 
@@ -74,6 +76,82 @@ func (this *ONTHandler) SyncGenesisHeader(native *native.NativeService) error {
 	return nil
 }
 
+// SyncCheckpointHeader seeds PutBlockHeader and UpdateConsensusPeer at a
+// governance-approved checkpoint instead of genesis, so SyncBlockHeader can
+// continue forward from there without replaying the whole header chain. It
+// also sets the trust anchor height (see trustAnchorHeightKey) to the checkpoint
+// height, so SyncBlockHeader refuses to (re-)sync anything at or below it -
+// the checkpoint becomes the new floor a corrupted or stale header chain
+// cannot be rebuilt under. This does not retroactively delete whatever
+// headers below the checkpoint were already stored; GetHeaderByHeight can
+// still return them.
+func (this *ONTHandler) SyncCheckpointHeader(native *native.NativeService) error {
+	params := new(hscommon.SyncCheckpointHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("SyncCheckpointHeader, contract params deserialize error: %v", err)
+	}
+
+	// the checkpoint is only trusted once it carries the multi-sig witness of
+	// the CURRENT poly consensus set read from governance, not whatever
+	// key set the caller happened to supply in params.Bookkeepers -
+	// otherwise anyone could self-sign an arbitrary checkpoint
+	currentPeers, err := node_manager.GetCurrentConsensusPeers(native)
+	if err != nil {
+		return fmt.Errorf("SyncCheckpointHeader, node_manager.GetCurrentConsensusPeers error: %v", err)
+	}
+	if !bookkeepersEqual(currentPeers, params.Bookkeepers) {
+		return fmt.Errorf("SyncCheckpointHeader, supplied bookkeepers do not match the current poly consensus set")
+	}
+	operatorAddress, err := types.AddressFromBookkeepers(currentPeers)
+	if err != nil {
+		return fmt.Errorf("SyncCheckpointHeader, AddressFromBookkeepers error: %v", err)
+	}
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return fmt.Errorf("SyncCheckpointHeader, checkWitness error: %v", err)
+	}
+
+	header, err := otypes.HeaderFromRawBytes(params.CheckpointHeader)
+	if err != nil {
+		return fmt.Errorf("SyncCheckpointHeader, deserialize header err: %v", err)
+	}
+
+	err = PutBlockHeader(native, header)
+	if err != nil {
+		return fmt.Errorf("SyncCheckpointHeader, put blockHeader error: %v", err)
+	}
+	err = UpdateConsensusPeer(native, header, params.Address)
+	if err != nil {
+		return fmt.Errorf("SyncCheckpointHeader, update ConsensusPeer error: %v", err)
+	}
+	err = putTrustAnchorHeight(native, header.ShardID, header.Height)
+	if err != nil {
+		return fmt.Errorf("SyncCheckpointHeader, put trust anchor height error: %v", err)
+	}
+	return nil
+}
+
+// bookkeepersEqual reports whether want and got serialize to the same set of
+// public keys, order independent.
+func bookkeepersEqual(want, got []keypair.PublicKey) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	index := make(map[string]bool, len(want))
+	for _, pk := range want {
+		index[string(keypair.SerializePublicKey(pk))] = true
+	}
+	for _, pk := range got {
+		key := string(keypair.SerializePublicKey(pk))
+		if !index[key] {
+			return false
+		}
+		// each key in want may be matched once, so duplicates in got fail
+		delete(index, key)
+	}
+	return true
+}
+
 func (this *ONTHandler) SyncBlockHeader(native *native.NativeService) error {
 	params := new(hscommon.SyncBlockHeaderParam)
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
@@ -84,6 +162,13 @@ func (this *ONTHandler) SyncBlockHeader(native *native.NativeService) error {
 		if err != nil {
 			return fmt.Errorf("SyncBlockHeader, otypes.HeaderFromRawBytes error: %v", err)
 		}
+		anchor, err := getTrustAnchorHeight(native, header.ShardID)
+		if err != nil {
+			return fmt.Errorf("SyncBlockHeader, get trust anchor height error: %v", err)
+		}
+		if header.Height <= anchor {
+			return fmt.Errorf("SyncBlockHeader, %d, %d is at or below the trust anchor height %d set by the last checkpoint", header.ShardID, header.Height, anchor)
+		}
 		_, err = GetHeaderByHeight(native, header.ShardID, header.Height)
 		if err == nil {
 			return fmt.Errorf("SyncBlockHeader, %d, %d", header.ShardID, header.Height)
@@ -103,3 +188,32 @@ func (this *ONTHandler) SyncBlockHeader(native *native.NativeService) error {
 	}
 	return nil
 }
+
+var trustAnchorHeightPrefix = []byte("ONTHeaderSyncTrustAnchorHeight")
+
+func trustAnchorHeightKey(shardID uint64) []byte {
+	key := make([]byte, 0, len(trustAnchorHeightPrefix)+8)
+	key = append(key, trustAnchorHeightPrefix...)
+	key = append(key, utils.GetUint64Bytes(shardID)...)
+	return key
+}
+
+// getTrustAnchorHeight returns the height of the last checkpoint synced for
+// shardID via SyncCheckpointHeader, or 0 if none has been synced yet, meaning
+// every height is still reachable from genesis.
+func getTrustAnchorHeight(native *native.NativeService, shardID uint64) (uint32, error) {
+	value, err := native.GetCacheDB().Get(trustAnchorHeightKey(shardID))
+	if err != nil {
+		return 0, fmt.Errorf("getTrustAnchorHeight, get trust anchor height error: %v", err)
+	}
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return utils.GetBytesUint32(value)
+}
+
+// putTrustAnchorHeight records height as the trust anchor for shardID, so
+// SyncBlockHeader refuses to sync anything at or below it from then on.
+func putTrustAnchorHeight(native *native.NativeService, shardID uint64, height uint32) error {
+	return native.GetCacheDB().Put(trustAnchorHeightKey(shardID), utils.GetUint32Bytes(height))
+}